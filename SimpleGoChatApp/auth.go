@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth tracks banned identities, an optional connection allowlist, and the
+// single admin identity allowed to run moderation commands.
+type Auth struct {
+	mu        sync.Mutex
+	bans      map[string]time.Time // zero time means a permanent ban
+	allowed   map[string]bool
+	allowlist bool
+	admin     string
+}
+
+// NewAuth creates an Auth with no bans and no allowlist restriction.
+// adminFingerprint may be empty, in which case no identity is an admin.
+func NewAuth(adminFingerprint string) *Auth {
+	return &Auth{
+		bans:  make(map[string]time.Time),
+		admin: adminFingerprint,
+	}
+}
+
+// LoadWhitelist reads one fingerprint, IP, or name per line from path and
+// restricts connections to only those identities. Blank lines and lines
+// starting with '#' are ignored.
+func (a *Auth) LoadWhitelist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	allowed := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowed[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.allowed = allowed
+	a.allowlist = true
+	return nil
+}
+
+// Allowed reports whether identity may connect. It is always true unless a
+// whitelist has been loaded.
+func (a *Auth) Allowed(identity string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.allowlist {
+		return true
+	}
+	return a.allowed[identity]
+}
+
+// allowWhitelisted adds identity to the allowlist, turning it on if this is
+// the first entry added at runtime.
+func (a *Auth) allowWhitelisted(identity string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.allowed == nil {
+		a.allowed = make(map[string]bool)
+	}
+	a.allowlist = true
+	a.allowed[identity] = true
+}
+
+// Ban blocks identity from connecting for d. A zero or negative duration
+// bans permanently.
+func (a *Auth) Ban(identity string, d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if d <= 0 {
+		a.bans[identity] = time.Time{}
+		return
+	}
+	a.bans[identity] = time.Now().Add(d)
+}
+
+// Banned reports whether identity is currently banned, clearing the entry
+// if its ban has since expired.
+func (a *Auth) Banned(identity string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	until, ok := a.bans[identity]
+	if !ok {
+		return false
+	}
+	if until.IsZero() {
+		return true
+	}
+	if time.Now().After(until) {
+		delete(a.bans, identity)
+		return false
+	}
+	return true
+}
+
+// IsAdmin reports whether identity is the configured admin.
+func (a *Auth) IsAdmin(identity string) bool {
+	return a.admin != "" && identity == a.admin
+}
+
+// parseBanArgs splits "/ban" style arguments into a target identity and an
+// optional duration, e.g. "alice 10m" -> ("alice", 10*time.Minute).
+func parseBanArgs(args string) (target string, dur time.Duration) {
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		return "", 0
+	}
+	target = parts[0]
+	if len(parts) > 1 {
+		if d, err := time.ParseDuration(parts[1]); err == nil {
+			dur = d
+		}
+	}
+	return target, dur
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuthBanPermanent(t *testing.T) {
+	a := NewAuth("")
+	a.Ban("1.2.3.4", 0)
+	if !a.Banned("1.2.3.4") {
+		t.Error("Banned() = false right after a permanent ban, want true")
+	}
+}
+
+func TestAuthBanExpires(t *testing.T) {
+	a := NewAuth("")
+	a.Ban("1.2.3.4", 10*time.Millisecond)
+	if !a.Banned("1.2.3.4") {
+		t.Fatal("Banned() = false right after banning, want true")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if a.Banned("1.2.3.4") {
+		t.Error("Banned() = true after the ban expired, want false")
+	}
+}
+
+func TestAuthBannedFalseForUnbannedIdentity(t *testing.T) {
+	a := NewAuth("")
+	if a.Banned("nobody") {
+		t.Error("Banned() = true for an identity that was never banned")
+	}
+}
+
+func TestAuthAllowedWithoutWhitelist(t *testing.T) {
+	a := NewAuth("")
+	if !a.Allowed("anyone") {
+		t.Error("Allowed() = false with no whitelist loaded, want true")
+	}
+}
+
+func TestAuthLoadWhitelistRestrictsToListedEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "whitelist")
+	contents := "# comment\n\nalice\n1.2.3.4\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	a := NewAuth("")
+	if err := a.LoadWhitelist(path); err != nil {
+		t.Fatalf("LoadWhitelist: %v", err)
+	}
+
+	if !a.Allowed("alice") {
+		t.Error("Allowed(\"alice\") = false, want true (listed)")
+	}
+	if !a.Allowed("1.2.3.4") {
+		t.Error("Allowed(\"1.2.3.4\") = false, want true (listed)")
+	}
+	if a.Allowed("mallory") {
+		t.Error("Allowed(\"mallory\") = true, want false (not listed)")
+	}
+}
+
+func TestAuthAllowWhitelistedAddsAtRuntime(t *testing.T) {
+	a := NewAuth("")
+	a.allowWhitelisted("bob")
+
+	if !a.Allowed("bob") {
+		t.Error("Allowed(\"bob\") = false after allowWhitelisted, want true")
+	}
+	if a.Allowed("mallory") {
+		t.Error("Allowed(\"mallory\") = true, want false now that an allowlist is active")
+	}
+}
+
+func TestAuthIsAdmin(t *testing.T) {
+	a := NewAuth("fp:admin")
+	if !a.IsAdmin("fp:admin") {
+		t.Error("IsAdmin(\"fp:admin\") = false, want true")
+	}
+	if a.IsAdmin("fp:someone-else") {
+		t.Error("IsAdmin(\"fp:someone-else\") = true, want false")
+	}
+}
+
+func TestAuthIsAdminFalseWhenUnconfigured(t *testing.T) {
+	a := NewAuth("")
+	if a.IsAdmin("") {
+		t.Error("IsAdmin(\"\") = true with no admin configured, want false")
+	}
+}
+
+func TestParseBanArgs(t *testing.T) {
+	cases := []struct {
+		args       string
+		wantTarget string
+		wantDur    time.Duration
+	}{
+		{"alice 10m", "alice", 10 * time.Minute},
+		{"alice", "alice", 0},
+		{"", "", 0},
+		{"alice not-a-duration", "alice", 0},
+	}
+	for _, c := range cases {
+		target, dur := parseBanArgs(c.args)
+		if target != c.wantTarget || dur != c.wantDur {
+			t.Errorf("parseBanArgs(%q) = (%q, %v), want (%q, %v)", c.args, target, dur, c.wantTarget, c.wantDur)
+		}
+	}
+}
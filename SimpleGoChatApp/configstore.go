@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Config holds one user's display preferences: prompt/message theme,
+// timestamp format, and whether they want to see join/leave notices.
+type Config struct {
+	Theme     string `json:"theme"`
+	Timestamp string `json:"timestamp"` // "on", "off", or "24h"
+	Quiet     bool   `json:"quiet"`
+}
+
+func DefaultConfig() Config {
+	return Config{Theme: "default", Timestamp: "on"}
+}
+
+// LiveConfig guards a connected user's Config with a mutex: /theme,
+// /timestamp, and /quiet update it from the session's goroutine while
+// Room.Broadcast reads it from every other connected user's goroutine to
+// render a message for them.
+type LiveConfig struct {
+	mu  sync.Mutex
+	cfg Config
+}
+
+func NewLiveConfig(cfg Config) *LiveConfig {
+	return &LiveConfig{cfg: cfg}
+}
+
+// Get returns a copy of the current Config.
+func (lc *LiveConfig) Get() Config {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.cfg
+}
+
+// Set replaces the current Config.
+func (lc *LiveConfig) Set(cfg Config) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.cfg = cfg
+}
+
+// ConfigStore persists per-user Config to disk, keyed by identity. A blank
+// dir makes every operation a no-op, so configs simply don't survive
+// restarts.
+type ConfigStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func NewConfigStore(dir string) *ConfigStore {
+	return &ConfigStore{dir: dir}
+}
+
+// Load returns the saved Config for identity, or DefaultConfig if none
+// exists.
+func (cs *ConfigStore) Load(identity string) Config {
+	if cs.dir == "" {
+		return DefaultConfig()
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	data, err := os.ReadFile(cs.path(identity))
+	if err != nil {
+		return DefaultConfig()
+	}
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultConfig()
+	}
+	return cfg
+}
+
+// Save persists cfg for identity.
+func (cs *ConfigStore) Save(identity string, cfg Config) error {
+	if cs.dir == "" {
+		return nil
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if err := os.MkdirAll(cs.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cs.path(identity), data, 0o644)
+}
+
+func (cs *ConfigStore) path(identity string) string {
+	return filepath.Join(cs.dir, sanitizeIdentity(identity)+".json")
+}
+
+// sanitizeIdentity maps an identity (which may contain ':', '/', etc., as
+// in a SSH fingerprint or "ip:port") to a safe filename component.
+func sanitizeIdentity(identity string) string {
+	var b strings.Builder
+	for _, r := range identity {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
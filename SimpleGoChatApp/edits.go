@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRegexCompileTime bounds how long /s is allowed to spend compiling a
+// user-supplied pattern.
+const maxRegexCompileTime = 100 * time.Millisecond
+
+// handleDelete implements "/d [n]": deleting the requesting user's last n
+// messages (default 1) in room, and broadcasting a notice for each.
+func handleDelete(w io.Writer, room *Room, user User, args string, window time.Duration) {
+	n := 1
+	if args = strings.TrimSpace(args); args != "" {
+		parsed, err := strconv.Atoi(args)
+		if err != nil || parsed < 1 {
+			fmt.Fprintln(w, "Usage: /d [n]")
+			return
+		}
+		n = parsed
+	}
+
+	deleted, err := room.DeleteLast(user.ID, n, window)
+	for _, msg := range deleted {
+		room.Broadcast(newMessage(user.Name, fmt.Sprintf("%s deleted their message from [%s].", user.Name, msg.Timestamp)))
+	}
+	if err != nil {
+		fmt.Fprintln(w, err)
+	}
+}
+
+// handleEdit implements "/s <regex> <replacement>": sed-style editing of the
+// requesting user's last message in room.
+func handleEdit(w io.Writer, room *Room, user User, args string, window time.Duration) {
+	parts := strings.SplitN(args, " ", 2)
+	if len(parts) != 2 {
+		fmt.Fprintln(w, "Usage: /s <regex> <replacement>")
+		return
+	}
+	pattern, replacement := parts[0], parts[1]
+
+	re, err := compileWithTimeout(pattern, maxRegexCompileTime)
+	if err != nil {
+		fmt.Fprintln(w, "Invalid regex:", err)
+		return
+	}
+
+	msg, err := room.EditLast(user.ID, re, replacement, window)
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return
+	}
+	room.Broadcast(newMessage(user.Name, fmt.Sprintf("%s edited their message: %s", user.Name, msg.Content)))
+}
+
+// compileWithTimeout compiles pattern, giving up if it takes longer than d.
+// Go's RE2-based regexp engine runs in linear time, so this mainly guards
+// against accidentally enormous patterns rather than catastrophic
+// backtracking.
+func compileWithTimeout(pattern string, d time.Duration) (*regexp.Regexp, error) {
+	type result struct {
+		re  *regexp.Regexp
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		re, err := regexp.Compile(pattern)
+		done <- result{re, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.re, r.err
+	case <-time.After(d):
+		return nil, fmt.Errorf("regex took too long to compile")
+	}
+}
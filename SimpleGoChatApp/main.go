@@ -2,8 +2,11 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"net"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,184 +18,532 @@ type User struct {
 }
 
 type Message struct {
+	ID        int64 // Store-assigned identifier, used to persist later edits/deletes
 	SenderID  string
 	Content   string
 	Timestamp string
+	At        time.Time // when the message was sent, for the edit window
+	Deleted   bool
+	System    bool // join/leave notice, suppressed by a viewer's /quiet
 }
 
+// ChatManagerConfig bundles the tunables that runSession needs but that
+// aren't per-room or per-connection state.
+type ChatManagerConfig struct {
+	EditWindow time.Duration // how far back /s and /d may edit a user's own messages
+
+	RateMsgsPerSec  float64       // sustained messages/sec allowed per connection
+	RateBytesPerSec float64       // sustained bytes/sec allowed per connection
+	MaxViolations   int           // rate-limit warnings tolerated before disconnecting
+	ViolationBan    time.Duration // ban applied after MaxViolations is exceeded
+
+	Store Store // message persistence backend; defaults to an in-memory store if nil
+
+	Configs *ConfigStore // per-user theme/timestamp/quiet preferences
+	MOTD    GetMOTD      // message of the day, sent once after registration
+}
+
+// ChatManager owns the set of named rooms and the global admin bookkeeping
+// (closers for /kick) that applies regardless of which room a user is in.
 type ChatManager struct {
-	messages    []Message
-	users       map[string]User
-	mu          sync.Mutex
-	clients     map[net.Conn]User
-	messageChan chan Message
+	mu      sync.Mutex
+	rooms   map[string]*Room
+	closers map[string]func()
+	// names maps a display name to the identities of every currently
+	// connected user registered under it, so /kick and /ban can be given a
+	// name. A name claimed by more than one connection is ambiguous.
+	names map[string]map[string]bool
+	cfg   ChatManagerConfig
 }
 
-func NewChatManager() *ChatManager {
-	return &ChatManager{
-		messages:    make([]Message, 0),
-		users:       make(map[string]User),
-		clients:     make(map[net.Conn]User),
-		messageChan: make(chan Message, 100),
+func NewChatManager(cfg ChatManagerConfig) *ChatManager {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Configs == nil {
+		cfg.Configs = NewConfigStore("")
+	}
+	if cfg.MOTD == nil {
+		cfg.MOTD = FileMOTD("")
+	}
+	cm := &ChatManager{
+		rooms:   make(map[string]*Room),
+		closers: make(map[string]func()),
+		names:   make(map[string]map[string]bool),
+		cfg:     cfg,
 	}
+	cm.rooms[defaultRoom] = NewRoom(defaultRoom, cfg.Store)
+	return cm
 }
 
-func (cm *ChatManager) StoreMessage(msg Message) {
+// Room returns the named room, creating it if this is the first time it has
+// been joined.
+func (cm *ChatManager) Room(name string) *Room {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
-	cm.messages = append(cm.messages, msg)
+	r, ok := cm.rooms[name]
+	if !ok {
+		r = NewRoom(name, cm.cfg.Store)
+		cm.rooms[name] = r
+	}
+	return r
 }
 
-func (cm *ChatManager) SearchMessages(query string, searchByUser bool) []string {
+// RoomNames lists every room that currently has at least one member, along
+// with its member count.
+func (cm *ChatManager) RoomNames() []string {
 	cm.mu.Lock()
-	defer cm.mu.Unlock()
+	rooms := make([]*Room, 0, len(cm.rooms))
+	for _, r := range cm.rooms {
+		rooms = append(rooms, r)
+	}
+	cm.mu.Unlock()
 
-	var results []string
-	for _, msg := range cm.messages {
-		if searchByUser {
-			if strings.Contains(msg.SenderID, query) {
-				results = append(results, fmt.Sprintf("[%s] %s: %s", msg.Timestamp, msg.SenderID, msg.Content))
-			}
-		} else {
-			if strings.Contains(msg.Content, query) {
-				results = append(results, fmt.Sprintf("[%s] %s: %s", msg.Timestamp, msg.SenderID, msg.Content))
-			}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].name < rooms[j].name })
+	names := make([]string, 0, len(rooms))
+	for _, r := range rooms {
+		if n := r.memberCount(); n > 0 {
+			names = append(names, fmt.Sprintf("%s (%d)", r.name, n))
 		}
 	}
-	return results
+	return names
+}
+
+func (cm *ChatManager) StoreMessage(room, senderIdentity string, msg Message) {
+	cm.Room(room).Store(senderIdentity, msg)
+}
+
+func (cm *ChatManager) SearchMessages(room, query string, searchByUser bool) []string {
+	return cm.Room(room).Search(query, searchByUser)
+}
+
+func (cm *ChatManager) BroadcastMessage(room string, msg Message) {
+	cm.Room(room).Broadcast(msg)
 }
 
-func (cm *ChatManager) RegisterUser(id, name string, conn net.Conn) User {
+// RegisterCloser remembers how to forcibly disconnect the user identified by
+// id (a SSH public-key fingerprint, or a TCP remote address), for /kick, and
+// indexes their display name so /kick and /ban can be given a name instead.
+func (cm *ChatManager) RegisterCloser(id, name string, closeFn func()) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
-	user := User{ID: id, Name: name}
-	cm.users[id] = user
-	cm.clients[conn] = user
-	return user
+	cm.closers[id] = closeFn
+	if cm.names[name] == nil {
+		cm.names[name] = make(map[string]bool)
+	}
+	cm.names[name][id] = true
 }
 
-func (cm *ChatManager) RemoveUser(conn net.Conn) {
+func (cm *ChatManager) RemoveCloser(id, name string) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
-	user := cm.clients[conn]
-	delete(cm.users, user.ID)
-	delete(cm.clients, conn)
+	delete(cm.closers, id)
+	delete(cm.names[name], id)
+	if len(cm.names[name]) == 0 {
+		delete(cm.names, name)
+	}
 }
 
-func (cm *ChatManager) BroadcastMessage(msg Message) {
+// ResolveIdentity maps a /kick or /ban target to a connection identity. If
+// target is a currently-connected user's display name claimed by exactly
+// one connection, that connection's identity is returned. If more than one
+// currently-connected user shares that name, resolution is refused as
+// ambiguous (ambiguous is true) rather than guessing which one was meant.
+// Otherwise target is assumed to already be an identity and is returned
+// unchanged.
+func (cm *ChatManager) ResolveIdentity(target string) (identity string, ambiguous bool) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
+	switch ids := cm.names[target]; len(ids) {
+	case 0:
+		return target, false
+	case 1:
+		for id := range ids {
+			return id, false
+		}
+	}
+	return "", true
+}
 
-	// Only broadcast to clients, don't store the message here
-	for conn := range cm.clients {
-		fmt.Fprintf(conn, "[%s] %s: %s\n", msg.Timestamp, msg.SenderID, msg.Content)
+// Kick forcibly disconnects the user identified by id, returning false if
+// no such user is currently connected.
+func (cm *ChatManager) Kick(id string) bool {
+	cm.mu.Lock()
+	closeFn, ok := cm.closers[id]
+	cm.mu.Unlock()
+	if !ok {
+		return false
 	}
+	closeFn()
+	return true
+}
+
+// session bundles everything the chat loop needs from an underlying
+// transport, letting handleClient (raw TCP) and handleSSHSession (SSH
+// channels) share the same command handling.
+type session struct {
+	rw       io.ReadWriter
+	identity string
+	closeFn  func()
 }
 
-func handleClient(conn net.Conn, cm *ChatManager) {
+func handleClient(conn net.Conn, cm *ChatManager, auth *Auth) {
 	defer conn.Close()
+	runSession(session{rw: conn, identity: remoteIP(conn), closeFn: func() { conn.Close() }}, cm, auth)
+}
+
+// remoteIP returns conn's remote address without its ephemeral source port,
+// so bans and whitelisting key on something stable across reconnects.
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
 
-	// Get the user's name
-	fmt.Fprint(conn, "Enter your name: ")
-	scanner := bufio.NewScanner(conn)
+// runSession registers the user behind s, runs the command/chat loop, and
+// cleans up on exit. It is transport-agnostic: s.rw may be a net.Conn or an
+// SSH channel.
+func runSession(s session, cm *ChatManager, auth *Auth) {
+	if auth.Banned(s.identity) {
+		fmt.Fprintln(s.rw, "You are banned from this server.")
+		return
+	}
+	if !auth.Allowed(s.identity) {
+		fmt.Fprintln(s.rw, "You are not on the whitelist for this server.")
+		return
+	}
+
+	fmt.Fprint(s.rw, "Enter your name: ")
+	scanner := bufio.NewScanner(s.rw)
+	scanner.Buffer(make([]byte, maxInputLength), maxInputLength)
 	scanner.Scan()
 	name := scanner.Text()
 
-	// Register the user
-	user := cm.RegisterUser(conn.RemoteAddr().String(), name, conn)
+	user := User{ID: s.identity, Name: name}
+	cm.RegisterCloser(user.ID, user.Name, s.closeFn)
+	defer cm.RemoveCloser(user.ID, user.Name)
+	isAdmin := auth.IsAdmin(s.identity)
+
+	cfg := NewLiveConfig(cm.cfg.Configs.Load(user.ID))
 
-	// Show command instructions once
-	fmt.Fprintln(conn, "Commands available:")
-	fmt.Fprintln(conn, "- Type 'exit' to leave")
-	fmt.Fprintln(conn, "- Type '/search <query>' to search by keyword")
-	fmt.Fprintln(conn, "- Type '/user <username>' to search by user")
-	fmt.Fprintln(conn, "- Type any other message to chat")
+	fmt.Fprintln(s.rw, "Commands available:")
+	fmt.Fprintln(s.rw, "- Type 'exit' to leave")
+	fmt.Fprintln(s.rw, "- Type '/search <query>' to search by keyword")
+	fmt.Fprintln(s.rw, "- Type '/user <username>' to search by user")
+	fmt.Fprintln(s.rw, "- Type '/join #room' to switch rooms, '/leave' to return to the lobby")
+	fmt.Fprintln(s.rw, "- Type '/rooms' to list rooms, '/who [#room]' to list members")
+	fmt.Fprintln(s.rw, "- Type '/d [n]' to delete your last n messages (default 1)")
+	fmt.Fprintln(s.rw, "- Type '/s <regex> <replacement>' to edit your last message")
+	fmt.Fprintln(s.rw, "- Type '/theme <name>', '/timestamp <on|off|24h>', '/quiet', or '/motd'")
+	if isAdmin {
+		fmt.Fprintln(s.rw, "- Type '/ban <name|ip|fp> [duration]' to ban someone")
+		fmt.Fprintln(s.rw, "- Type '/kick <name>' to disconnect someone")
+		fmt.Fprintln(s.rw, "- Type '/allow <fp>' to add to the whitelist")
+	}
+	fmt.Fprintln(s.rw, "- Type any other message to chat")
 
-	// Notify others
-	msg := Message{
-		SenderID:  user.Name,
-		Content:   fmt.Sprintf("%s has joined.", user.Name),
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	if motd, err := cm.cfg.MOTD(); err == nil && motd != "" {
+		fmt.Fprintln(s.rw, motd)
 	}
-	cm.BroadcastMessage(msg)
 
-	// Handle incoming messages from the client
+	roomName := defaultRoom
+	room := joinRoom(cm, s.rw, roomName, user, cfg)
+
+	limiter := NewRateLimiter(cm.cfg.RateMsgsPerSec, cm.cfg.RateBytesPerSec)
+	violations := 0
+
 	for {
-		fmt.Fprint(conn, "> ")  // Simple prompt for messages
-		scanner.Scan()
+		fmt.Fprint(s.rw, GetPrompt(cfg.Get()))
+		if !scanner.Scan() {
+			if scanner.Err() != nil {
+				fmt.Fprintln(s.rw, "Input too long; disconnecting.")
+			}
+			break
+		}
 		message := scanner.Text()
 
-		// Exit condition
 		if message == "exit" {
 			break
 		}
 
-		// Search by keyword command
+		if !limiter.Allow(len(message)) {
+			violations++
+			fmt.Fprintln(s.rw, "You're sending messages too fast, slow down.")
+			if violations > cm.cfg.MaxViolations {
+				auth.Ban(s.identity, cm.cfg.ViolationBan)
+				fmt.Fprintln(s.rw, "Disconnected for repeated flooding.")
+				break
+			}
+			continue
+		}
+
 		if strings.HasPrefix(message, "/search ") {
 			query := strings.TrimPrefix(message, "/search ")
-			results := cm.SearchMessages(query, false)
-
-			// Send the search results only to the requesting client
-			if len(results) > 0 {
-				fmt.Fprintln(conn, "Search results by keyword:")
-				for _, result := range results {
-					fmt.Fprintln(conn, result)
-				}
-			} else {
-				fmt.Fprintln(conn, "No results found.")
-			}
+			writeSearchResults(s.rw, cm.SearchMessages(roomName, query, false), "keyword")
 			continue
 		}
 
-		// Search by user command
 		if strings.HasPrefix(message, "/user ") {
 			query := strings.TrimPrefix(message, "/user ")
-			results := cm.SearchMessages(query, true)
-
-			// Send the search results only to the requesting client
-			if len(results) > 0 {
-				fmt.Fprintln(conn, "Search results by user:")
-				for _, result := range results {
-					fmt.Fprintln(conn, result)
-				}
+			writeSearchResults(s.rw, cm.SearchMessages(roomName, query, true), "user")
+			continue
+		}
+
+		if strings.HasPrefix(message, "/join ") {
+			newRoom := normalizeRoomName(strings.TrimPrefix(message, "/join "))
+			leaveRoom(room, s.rw, user, roomName)
+			roomName = newRoom
+			room = joinRoom(cm, s.rw, roomName, user, cfg)
+			continue
+		}
+
+		if message == "/leave" {
+			leaveRoom(room, s.rw, user, roomName)
+			roomName = defaultRoom
+			room = joinRoom(cm, s.rw, roomName, user, cfg)
+			continue
+		}
+
+		if message == "/rooms" {
+			fmt.Fprintln(s.rw, "Rooms:", strings.Join(cm.RoomNames(), ", "))
+			continue
+		}
+
+		if message == "/who" || strings.HasPrefix(message, "/who ") {
+			target := roomName
+			if args := strings.TrimPrefix(message, "/who "); args != message {
+				target = normalizeRoomName(args)
+			}
+			fmt.Fprintf(s.rw, "Members of %s: %s\n", target, strings.Join(cm.Room(target).Who(), ", "))
+			continue
+		}
+
+		if message == "/d" || strings.HasPrefix(message, "/d ") {
+			handleDelete(s.rw, room, user, strings.TrimPrefix(message, "/d"), cm.cfg.EditWindow)
+			continue
+		}
+
+		if strings.HasPrefix(message, "/s ") {
+			handleEdit(s.rw, room, user, strings.TrimPrefix(message, "/s "), cm.cfg.EditWindow)
+			continue
+		}
+
+		if strings.HasPrefix(message, "/theme ") {
+			name := strings.TrimPrefix(message, "/theme ")
+			if _, ok := lookupTheme(name); !ok {
+				fmt.Fprintln(s.rw, "Unknown theme:", name)
+				continue
+			}
+			updated := cfg.Get()
+			updated.Theme = name
+			cfg.Set(updated)
+			saveConfig(s.rw, cm, user.ID, updated)
+			continue
+		}
+
+		if strings.HasPrefix(message, "/timestamp ") {
+			mode := strings.TrimPrefix(message, "/timestamp ")
+			if mode != "on" && mode != "off" && mode != "24h" {
+				fmt.Fprintln(s.rw, "Usage: /timestamp <on|off|24h>")
+				continue
+			}
+			updated := cfg.Get()
+			updated.Timestamp = mode
+			cfg.Set(updated)
+			saveConfig(s.rw, cm, user.ID, updated)
+			continue
+		}
+
+		if message == "/quiet" {
+			updated := cfg.Get()
+			updated.Quiet = !updated.Quiet
+			cfg.Set(updated)
+			saveConfig(s.rw, cm, user.ID, updated)
+			fmt.Fprintln(s.rw, "Quiet mode:", updated.Quiet)
+			continue
+		}
+
+		if message == "/motd" {
+			motd, err := cm.cfg.MOTD()
+			if err != nil {
+				fmt.Fprintln(s.rw, "Error loading MOTD:", err)
+			} else if motd == "" {
+				fmt.Fprintln(s.rw, "No MOTD configured.")
+			} else {
+				fmt.Fprintln(s.rw, motd)
+			}
+			continue
+		}
+
+		if isAdmin && strings.HasPrefix(message, "/ban ") {
+			target, dur := parseBanArgs(strings.TrimPrefix(message, "/ban "))
+			identity, ambiguous := cm.ResolveIdentity(target)
+			if ambiguous {
+				fmt.Fprintf(s.rw, "%s matches more than one connected user; ban by fingerprint or IP instead.\n", target)
+				continue
+			}
+			auth.Ban(identity, dur)
+			cm.Kick(identity)
+			fmt.Fprintf(s.rw, "Banned %s.\n", target)
+			continue
+		}
+
+		if isAdmin && strings.HasPrefix(message, "/kick ") {
+			target := strings.TrimPrefix(message, "/kick ")
+			identity, ambiguous := cm.ResolveIdentity(target)
+			if ambiguous {
+				fmt.Fprintf(s.rw, "%s matches more than one connected user; kick by fingerprint or IP instead.\n", target)
+				continue
+			}
+			if cm.Kick(identity) {
+				fmt.Fprintf(s.rw, "Kicked %s.\n", target)
 			} else {
-				fmt.Fprintln(conn, "No results found.")
+				fmt.Fprintf(s.rw, "%s is not connected.\n", target)
 			}
 			continue
 		}
 
-		// Store and broadcast the message
-		msg := Message{
-			SenderID:  user.Name,
-			Content:   message,
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		if isAdmin && strings.HasPrefix(message, "/allow ") {
+			fp := strings.TrimPrefix(message, "/allow ")
+			auth.allowWhitelisted(fp)
+			fmt.Fprintf(s.rw, "Allowed %s.\n", fp)
+			continue
 		}
-		cm.StoreMessage(msg)  // Store the message
-		cm.BroadcastMessage(msg)  // Broadcast to all clients
+
+		msg := newMessage(user.Name, message)
+		cm.StoreMessage(roomName, user.ID, msg)
+		cm.BroadcastMessage(roomName, msg)
 	}
 
-	// Remove the user when they disconnect
-	cm.RemoveUser(conn)
-	msg = Message{
-		SenderID:  user.Name,
-		Content:   fmt.Sprintf("%s has left.", user.Name),
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	leaveRoom(room, s.rw, user, roomName)
+}
+
+// saveConfig persists a user's updated Config, reporting any error back to
+// them rather than failing the command they were running.
+func saveConfig(w io.Writer, cm *ChatManager, identity string, cfg Config) {
+	if err := cm.cfg.Configs.Save(identity, cfg); err != nil {
+		fmt.Fprintln(w, "Error saving preferences:", err)
+	}
+}
+
+// timestampLayout is the display/storage format used for every Message
+// timestamp.
+const timestampLayout = "2006-01-02 15:04:05"
+
+// newMessage builds a Message timestamped at the current time.
+func newMessage(sender, content string) Message {
+	now := time.Now()
+	return Message{
+		SenderID:  sender,
+		Content:   content,
+		Timestamp: now.Format(timestampLayout),
+		At:        now,
+	}
+}
+
+// newSystemMessage builds a join/leave notice, which a viewer's /quiet
+// setting may suppress.
+func newSystemMessage(sender, content string) Message {
+	msg := newMessage(sender, content)
+	msg.System = true
+	return msg
+}
+
+// joinRoom adds user to roomName, replays its recent history to w, and
+// announces the join to the room.
+func joinRoom(cm *ChatManager, w io.Writer, roomName string, user User, cfg *LiveConfig) *Room {
+	room := cm.Room(roomName)
+	room.Join(w, user, cfg)
+
+	history := room.Recent(historyReplayCount)
+	if len(history) > 0 {
+		fmt.Fprintf(w, "-- last %d messages in %s --\n", len(history), roomName)
+		for _, msg := range history {
+			fmt.Fprintln(w, RenderMessage(msg, cfg.Get()))
+		}
 	}
-	cm.BroadcastMessage(msg)
+
+	room.Broadcast(newSystemMessage(user.Name, fmt.Sprintf("%s has joined %s.", user.Name, roomName)))
+	return room
+}
+
+func leaveRoom(room *Room, w io.Writer, user User, roomName string) {
+	room.Leave(w)
+	room.Broadcast(newSystemMessage(user.Name, fmt.Sprintf("%s has left %s.", user.Name, roomName)))
+}
+
+func writeSearchResults(w io.Writer, results []string, kind string) {
+	if len(results) > 0 {
+		fmt.Fprintf(w, "Search results by %s:\n", kind)
+		for _, result := range results {
+			fmt.Fprintln(w, result)
+		}
+		return
+	}
+	fmt.Fprintln(w, "No results found.")
 }
 
 func main() {
-	chatManager := NewChatManager()
+	addr := flag.String("addr", ":8080", "TCP address to listen on")
+	sshAddr := flag.String("ssh-addr", "", "SSH address to listen on, e.g. :2022 (disabled if empty)")
+	admin := flag.String("admin", "", "SSH public-key fingerprint granted admin commands")
+	whitelist := flag.String("whitelist", "", "path to a file of allowed fingerprints/IPs/names (disabled if empty)")
+	editWindow := flag.Duration("edit-window", 5*time.Minute, "how long after sending a message /s and /d may still act on it")
+	rateMsgs := flag.Float64("rate-msgs", 5, "sustained messages/sec allowed per connection")
+	rateBytes := flag.Float64("rate-bytes", 4096, "sustained bytes/sec allowed per connection")
+	maxViolations := flag.Int("max-violations", 5, "rate-limit warnings tolerated before disconnecting")
+	violationBan := flag.Duration("violation-ban", 10*time.Minute, "ban applied after max-violations is exceeded")
+	dbPath := flag.String("db", "", "path to a SQLite database for persistent history (in-memory if empty)")
+	configDir := flag.String("config-dir", "", "directory to persist per-user theme/timestamp/quiet preferences (in-memory only if empty)")
+	motdPath := flag.String("motd", "", "path to a message-of-the-day file sent after registration (disabled if empty)")
+	flag.Parse()
+
+	var store Store = NewMemoryStore()
+	if *dbPath != "" {
+		sqliteStore, err := NewSQLiteStore(*dbPath)
+		if err != nil {
+			fmt.Println("Error opening database:", err)
+			return
+		}
+		store = sqliteStore
+	}
+
+	chatManager := NewChatManager(ChatManagerConfig{
+		EditWindow:      *editWindow,
+		RateMsgsPerSec:  *rateMsgs,
+		RateBytesPerSec: *rateBytes,
+		MaxViolations:   *maxViolations,
+		ViolationBan:    *violationBan,
+		Store:           store,
+		Configs:         NewConfigStore(*configDir),
+		MOTD:            FileMOTD(*motdPath),
+	})
+	auth := NewAuth(*admin)
+	if *whitelist != "" {
+		if err := auth.LoadWhitelist(*whitelist); err != nil {
+			fmt.Println("Error loading whitelist:", err)
+			return
+		}
+	}
+
+	if *sshAddr != "" {
+		go func() {
+			if err := ListenAndServeSSH(*sshAddr, chatManager, auth); err != nil {
+				fmt.Println("Error starting SSH server:", err)
+			}
+		}()
+	}
 
-	// Start the server
-	ln, err := net.Listen("tcp", ":8080")
+	ln, err := net.Listen("tcp", *addr)
 	if err != nil {
 		fmt.Println("Error starting server:", err)
 		return
 	}
 	defer ln.Close()
 
-	fmt.Println("Server is listening on port 8080...")
+	fmt.Printf("Server is listening on %s...\n", *addr)
 
 	for {
 		conn, err := ln.Accept()
@@ -200,6 +551,6 @@ func main() {
 			fmt.Println("Error accepting connection:", err)
 			continue
 		}
-		go handleClient(conn, chatManager)
+		go handleClient(conn, chatManager, auth)
 	}
 }
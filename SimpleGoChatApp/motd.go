@@ -0,0 +1,23 @@
+package main
+
+import "os"
+
+// GetMOTD fetches the current message of the day. It's a function, not a
+// fixed string, so the file backing it can be edited and picked up without
+// restarting the server.
+type GetMOTD func() (string, error)
+
+// FileMOTD builds a GetMOTD that re-reads path on every call. An empty path
+// means no MOTD is configured.
+func FileMOTD(path string) GetMOTD {
+	return func() (string, error) {
+		if path == "" {
+			return "", nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// maxInputLength is the largest line accepted from a client, in bytes,
+// counting its trailing newline.
+const maxInputLength = 1024
+
+// TokenBucket is a simple token-bucket rate limiter: tokens refill
+// continuously up to a burst capacity, and each Allow call spends tokens
+// against it.
+type TokenBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens added per second
+	last     time.Time
+}
+
+func NewTokenBucket(rate, capacity float64) *TokenBucket {
+	return &TokenBucket{tokens: capacity, capacity: capacity, rate: rate, last: time.Now()}
+}
+
+// Allow reports whether n tokens are available right now, spending them if
+// so.
+func (b *TokenBucket) Allow(n float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += b.rate * now.Sub(b.last).Seconds()
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// RateLimiter bounds both the message rate and the byte rate of a single
+// connection, to keep one client from flooding the server.
+type RateLimiter struct {
+	messages *TokenBucket
+	bytes    *TokenBucket
+}
+
+func NewRateLimiter(msgsPerSec, bytesPerSec float64) *RateLimiter {
+	return &RateLimiter{
+		messages: NewTokenBucket(msgsPerSec, msgsPerSec),
+		bytes:    NewTokenBucket(bytesPerSec, bytesPerSec),
+	}
+}
+
+// Allow reports whether a message of n bytes may be accepted right now.
+func (rl *RateLimiter) Allow(n int) bool {
+	okMsg := rl.messages.Allow(1)
+	okBytes := rl.bytes.Allow(float64(n))
+	return okMsg && okBytes
+}
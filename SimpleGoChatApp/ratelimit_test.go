@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsUpToCapacity(t *testing.T) {
+	b := NewTokenBucket(1, 3)
+	for i := 0; i < 3; i++ {
+		if !b.Allow(1) {
+			t.Fatalf("Allow() = false on call %d, want true within burst capacity", i+1)
+		}
+	}
+	if b.Allow(1) {
+		t.Error("Allow() = true once capacity is exhausted, want false")
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	b := NewTokenBucket(1000, 1) // refills fast enough that a short sleep is sufficient
+	if !b.Allow(1) {
+		t.Fatal("Allow() = false on first call, want true")
+	}
+	if b.Allow(1) {
+		t.Fatal("Allow() = true immediately after exhausting capacity, want false")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if !b.Allow(1) {
+		t.Error("Allow() = false after refill, want true")
+	}
+}
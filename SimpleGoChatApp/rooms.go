@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRoom is joined automatically by every connecting user, preserving
+// the single-channel behavior of earlier versions of this server.
+const defaultRoom = "#lobby"
+
+// historyReplayCount is how many recent messages a room replays to a user
+// who just joined it.
+const historyReplayCount = 20
+
+// searchResultLimit caps how many matches /search and /user return.
+const searchResultLimit = 50
+
+// client is a room member: their identity and the per-user LiveConfig, so
+// changes from /theme, /timestamp, and /quiet take effect immediately.
+type client struct {
+	user User
+	cfg  *LiveConfig
+}
+
+// Room holds the connected clients for one named chat channel, plus the
+// in-memory index of each user's own messages that /s and /d edit. Message
+// history itself lives in a Store, which may be persistent.
+type Room struct {
+	name    string
+	mu      sync.Mutex
+	store   Store
+	byUser  map[string][]*Message // sender identity (user.ID) -> their own messages, oldest first
+	clients map[io.Writer]client
+}
+
+func NewRoom(name string, store Store) *Room {
+	return &Room{
+		name:    name,
+		store:   store,
+		byUser:  make(map[string][]*Message),
+		clients: make(map[io.Writer]client),
+	}
+}
+
+// Store persists msg and indexes it under senderIdentity (the sender's
+// stable connection identity, not their display name) so a later /s or /d
+// from that same connection can find it. Display name is not a safe index:
+// nothing stops a second connection from registering with a name already in
+// use, and indexing by name would let it edit or delete the real owner's
+// messages.
+func (r *Room) Store(senderIdentity string, msg Message) *Message {
+	id, err := r.store.Append(r.name, msg)
+	if err != nil {
+		fmt.Println("Error persisting message:", err)
+	}
+	msg.ID = id
+
+	r.mu.Lock()
+	m := &msg
+	r.byUser[senderIdentity] = append(r.byUser[senderIdentity], m)
+	r.mu.Unlock()
+	return m
+}
+
+func (r *Room) Search(query string, searchByUser bool) []string {
+	msgs, err := r.store.Search(r.name, query, searchByUser, searchResultLimit)
+	if err != nil {
+		fmt.Println("Error searching messages:", err)
+		return nil
+	}
+
+	results := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		results = append(results, fmt.Sprintf("[%s] %s: %s", msg.Timestamp, msg.SenderID, msg.Content))
+	}
+	return results
+}
+
+// EditLast applies re.ReplaceAllString to senderIdentity's most recent
+// non-deleted message in the room and returns the updated message. It
+// refuses to edit messages older than window. The edit is written through
+// to the Store so it sticks in search results and future history replays.
+func (r *Room) EditLast(senderIdentity string, re *regexp.Regexp, replacement string, window time.Duration) (*Message, error) {
+	r.mu.Lock()
+	msg := lastNonDeleted(r.byUser[senderIdentity], 0)
+	if msg == nil {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("you have no messages to edit")
+	}
+	if time.Since(msg.At) > window {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("message is too old to edit")
+	}
+	msg.Content = re.ReplaceAllString(msg.Content, replacement)
+	id, content := msg.ID, msg.Content
+	r.mu.Unlock()
+
+	if err := r.store.Update(r.name, id, content); err != nil {
+		fmt.Println("Error persisting edit:", err)
+	}
+	return msg, nil
+}
+
+// DeleteLast marks senderIdentity's last n non-deleted messages as deleted
+// and returns them, refusing any older than window. Each deletion is
+// written through to the Store so it stops appearing in search results and
+// future history replays.
+func (r *Room) DeleteLast(senderIdentity string, n int, window time.Duration) ([]*Message, error) {
+	r.mu.Lock()
+	var deleted []*Message
+	var windowErr error
+	for i := 0; i < n; i++ {
+		msg := lastNonDeleted(r.byUser[senderIdentity], 0)
+		if msg == nil {
+			break
+		}
+		if time.Since(msg.At) > window {
+			windowErr = fmt.Errorf("no more messages within the edit window")
+			break
+		}
+		msg.Deleted = true
+		deleted = append(deleted, msg)
+	}
+	r.mu.Unlock()
+
+	for _, msg := range deleted {
+		if err := r.store.Delete(r.name, msg.ID); err != nil {
+			fmt.Println("Error persisting delete:", err)
+		}
+	}
+	if len(deleted) == 0 {
+		if windowErr != nil {
+			return nil, windowErr
+		}
+		return nil, fmt.Errorf("you have no messages to delete")
+	}
+	return deleted, windowErr
+}
+
+// lastNonDeleted returns the most recent non-deleted message in msgs,
+// skipping the first `skip` non-deleted messages found from the end.
+func lastNonDeleted(msgs []*Message, skip int) *Message {
+	seen := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Deleted {
+			continue
+		}
+		if seen == skip {
+			return msgs[i]
+		}
+		seen++
+	}
+	return nil
+}
+
+// Broadcast renders msg for each member using their own Config, skipping
+// members who have gone /quiet if msg is a join/leave notice.
+func (r *Room) Broadcast(msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for w, c := range r.clients {
+		cfg := c.cfg.Get()
+		if msg.System && cfg.Quiet {
+			continue
+		}
+		fmt.Fprintln(w, RenderMessage(msg, cfg))
+	}
+}
+
+func (r *Room) Join(w io.Writer, user User, cfg *LiveConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[w] = client{user: user, cfg: cfg}
+}
+
+func (r *Room) Leave(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, w)
+}
+
+// Recent returns the last n messages stored in the room, oldest first.
+func (r *Room) Recent(n int) []Message {
+	msgs, err := r.store.Recent(r.name, n)
+	if err != nil {
+		fmt.Println("Error loading room history:", err)
+		return nil
+	}
+	return msgs
+}
+
+// Who lists the names of users currently connected to the room.
+func (r *Room) Who() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.clients))
+	for _, c := range r.clients {
+		names = append(names, c.user.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Room) memberCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.clients)
+}
+
+// normalizeRoomName ensures a room name is non-empty and '#'-prefixed,
+// defaulting to the lobby.
+func normalizeRoomName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return defaultRoom
+	}
+	if !strings.HasPrefix(name, "#") {
+		name = "#" + name
+	}
+	return name
+}
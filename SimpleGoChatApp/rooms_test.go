@@ -0,0 +1,108 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRoomEditLastAppliesWithinWindow(t *testing.T) {
+	room := NewRoom("#test", NewMemoryStore())
+	room.Store("alice-id", Message{SenderID: "alice", Content: "helo", At: time.Now()})
+
+	re := regexp.MustCompile("helo")
+	msg, err := room.EditLast("alice-id", re, "hello", time.Minute)
+	if err != nil {
+		t.Fatalf("EditLast: %v", err)
+	}
+	if msg.Content != "hello" {
+		t.Errorf("Content = %q, want %q", msg.Content, "hello")
+	}
+}
+
+func TestRoomEditLastRefusesStaleMessage(t *testing.T) {
+	room := NewRoom("#test", NewMemoryStore())
+	room.Store("alice-id", Message{SenderID: "alice", Content: "helo", At: time.Now().Add(-time.Hour)})
+
+	re := regexp.MustCompile("helo")
+	if _, err := room.EditLast("alice-id", re, "hello", time.Minute); err == nil {
+		t.Fatal("expected error editing a message older than the edit window")
+	}
+}
+
+func TestRoomEditLastPersistsToStore(t *testing.T) {
+	store := NewMemoryStore()
+	room := NewRoom("#test", store)
+	room.Store("alice-id", Message{SenderID: "alice", Content: "helo", At: time.Now()})
+
+	re := regexp.MustCompile("helo")
+	if _, err := room.EditLast("alice-id", re, "hello", time.Minute); err != nil {
+		t.Fatalf("EditLast: %v", err)
+	}
+
+	results, err := store.Search("#test", "hello", false, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Search returned %d results, want 1 reflecting the edit", len(results))
+	}
+}
+
+func TestRoomDeleteLastRefusesBeyondWindow(t *testing.T) {
+	room := NewRoom("#test", NewMemoryStore())
+	room.Store("alice-id", Message{SenderID: "alice", Content: "old", At: time.Now().Add(-time.Hour)})
+	room.Store("alice-id", Message{SenderID: "alice", Content: "new", At: time.Now()})
+
+	deleted, err := room.DeleteLast("alice-id", 2, time.Minute)
+	if err == nil {
+		t.Fatal("expected error when deleting past the edit window")
+	}
+	if len(deleted) != 1 {
+		t.Fatalf("len(deleted) = %d, want 1", len(deleted))
+	}
+	if !deleted[0].Deleted {
+		t.Error("deleted message not marked Deleted")
+	}
+}
+
+func TestRoomDeleteLastPersistsToStore(t *testing.T) {
+	store := NewMemoryStore()
+	room := NewRoom("#test", store)
+	room.Store("alice-id", Message{SenderID: "alice", Content: "oops", At: time.Now()})
+
+	if _, err := room.DeleteLast("alice-id", 1, time.Minute); err != nil {
+		t.Fatalf("DeleteLast: %v", err)
+	}
+
+	results, err := store.Search("#test", "oops", false, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("Search returned %d results, want 0 for a deleted message", len(results))
+	}
+}
+
+// TestRoomEditLastIgnoresNameCollision guards against a second connection
+// that simply registers with a display name already in use: its own
+// identity has no messages of its own to edit, even though it shares a
+// display name with a user who does.
+func TestRoomEditLastIgnoresNameCollision(t *testing.T) {
+	room := NewRoom("#test", NewMemoryStore())
+	room.Store("alice-real-id", Message{SenderID: "alice", Content: "hello", At: time.Now()})
+
+	re := regexp.MustCompile("hello")
+	if _, err := room.EditLast("alice-impostor-id", re, "pwned", time.Minute); err == nil {
+		t.Fatal("expected error: impostor identity has no messages of its own to edit")
+	}
+}
+
+func TestRoomDeleteLastIgnoresNameCollision(t *testing.T) {
+	room := NewRoom("#test", NewMemoryStore())
+	room.Store("alice-real-id", Message{SenderID: "alice", Content: "hello", At: time.Now()})
+
+	if _, err := room.DeleteLast("alice-impostor-id", 1, time.Minute); err == nil {
+		t.Fatal("expected error: impostor identity has no messages of its own to delete")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStore is a Store backed by a SQLite database, with a full-text
+// search index over message content.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at path
+// and ensures its schema is up to date.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func migrateSQLite(db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			fingerprint TEXT UNIQUE,
+			first_seen DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			room TEXT NOT NULL,
+			sender TEXT NOT NULL,
+			content TEXT NOT NULL,
+			ts TEXT NOT NULL,
+			deleted INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS messages_room_idx ON messages(room)`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+			content, content='messages', content_rowid='id'
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS messages_ai AFTER INSERT ON messages BEGIN
+			INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS messages_au AFTER UPDATE ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content) VALUES('delete', old.id, old.content);
+			INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+		END`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("running migration %q: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Append(room string, msg Message) (int64, error) {
+	res, err := s.db.Exec(
+		`INSERT INTO messages (room, sender, content, ts) VALUES (?, ?, ?, ?)`,
+		room, msg.SenderID, msg.Content, msg.Timestamp,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *SQLiteStore) Update(room string, id int64, content string) error {
+	_, err := s.db.Exec(`UPDATE messages SET content = ? WHERE id = ? AND room = ?`, content, id, room)
+	return err
+}
+
+func (s *SQLiteStore) Delete(room string, id int64) error {
+	_, err := s.db.Exec(`UPDATE messages SET deleted = 1 WHERE id = ? AND room = ?`, id, room)
+	return err
+}
+
+func (s *SQLiteStore) Search(room, query string, byUser bool, limit int) ([]Message, error) {
+	var rows *sql.Rows
+	var err error
+	if byUser {
+		rows, err = s.db.Query(
+			`SELECT sender, content, ts FROM messages
+			 WHERE room = ? AND sender LIKE ? AND deleted = 0
+			 ORDER BY id DESC LIMIT ?`,
+			room, "%"+query+"%", limit,
+		)
+	} else {
+		rows, err = s.db.Query(
+			`SELECT m.sender, m.content, m.ts FROM messages m
+			 JOIN messages_fts f ON f.rowid = m.id
+			 WHERE m.room = ? AND messages_fts MATCH ? AND m.deleted = 0
+			 ORDER BY m.id DESC LIMIT ?`,
+			room, query, limit,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+func (s *SQLiteStore) Recent(room string, n int) ([]Message, error) {
+	rows, err := s.db.Query(
+		`SELECT sender, content, ts FROM messages WHERE room = ? AND deleted = 0 ORDER BY id DESC LIMIT ?`,
+		room, n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	return msgs, nil
+}
+
+func scanMessages(rows *sql.Rows) ([]Message, error) {
+	defer rows.Close()
+
+	var msgs []Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.SenderID, &msg.Content, &msg.Timestamp); err != nil {
+			return nil, err
+		}
+		if at, err := time.Parse(timestampLayout, msg.Timestamp); err == nil {
+			msg.At = at
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
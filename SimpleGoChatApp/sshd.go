@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ListenAndServeSSH starts an SSH listener on addr and serves chat sessions
+// over it, identifying each connecting user by the SHA256 fingerprint of
+// their public key instead of a remote address.
+func ListenAndServeSSH(addr string, cm *ChatManager, auth *Auth) error {
+	signer, err := newHostKey()
+	if err != nil {
+		return fmt.Errorf("generating SSH host key: %w", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			fp := ssh.FingerprintSHA256(key)
+			if auth.Banned(fp) {
+				return nil, fmt.Errorf("banned")
+			}
+			if !auth.Allowed(fp) {
+				return nil, fmt.Errorf("not whitelisted")
+			}
+			return &ssh.Permissions{Extensions: map[string]string{"fingerprint": fp}}, nil
+		},
+		NoClientAuth: false,
+	}
+	config.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	fmt.Printf("SSH server is listening on %s...\n", addr)
+
+	for {
+		nConn, err := ln.Accept()
+		if err != nil {
+			fmt.Println("Error accepting SSH connection:", err)
+			continue
+		}
+		go handleSSHConn(nConn, config, cm, auth)
+	}
+}
+
+func handleSSHConn(nConn net.Conn, config *ssh.ServerConfig, cm *ChatManager, auth *Auth) {
+	sshConn, chans, reqs, err := ssh.NewServerConn(nConn, config)
+	if err != nil {
+		nConn.Close()
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	fingerprint := sshConn.Permissions.Extensions["fingerprint"]
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go acceptShellRequests(requests)
+		go runSession(session{rw: channel, identity: fingerprint, closeFn: func() { sshConn.Close() }}, cm, auth)
+	}
+}
+
+// acceptShellRequests acknowledges pty/shell requests so interactive SSH
+// clients start sending input immediately, ignoring anything else.
+func acceptShellRequests(requests <-chan *ssh.Request) {
+	for req := range requests {
+		switch req.Type {
+		case "shell", "pty-req":
+			req.Reply(true, nil)
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// newHostKey generates a throwaway RSA host key. A real deployment would
+// load a persistent key from disk instead.
+func newHostKey() (ssh.Signer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(key)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// Store persists chat history so it can be searched and replayed across
+// restarts. MemoryStore is the default; SQLiteStore backs it with a real
+// database. Append returns the persisted message's id, which Update and
+// Delete use to apply a later /s or /d to the same row.
+type Store interface {
+	Append(room string, msg Message) (id int64, err error)
+	Update(room string, id int64, content string) error
+	Delete(room string, id int64) error
+	Search(room, query string, byUser bool, limit int) ([]Message, error)
+	Recent(room string, n int) ([]Message, error)
+}
+
+// MemoryStore is the in-memory Store used when no --db flag is given, and
+// in tests that don't want a database dependency.
+type MemoryStore struct {
+	mu       sync.Mutex
+	nextID   int64
+	messages map[string][]Message // room -> messages, oldest first
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{messages: make(map[string][]Message)}
+}
+
+func (s *MemoryStore) Append(room string, msg Message) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	msg.ID = s.nextID
+	s.messages[room] = append(s.messages[room], msg)
+	return msg.ID, nil
+}
+
+func (s *MemoryStore) Update(room string, id int64, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, msg := range s.messages[room] {
+		if msg.ID == id {
+			s.messages[room][i].Content = content
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(room string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, msg := range s.messages[room] {
+		if msg.ID == id {
+			s.messages[room][i].Deleted = true
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Search(room, query string, byUser bool, limit int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Message
+	for _, msg := range s.messages[room] {
+		if msg.Deleted {
+			continue
+		}
+		field := msg.Content
+		if byUser {
+			field = msg.SenderID
+		}
+		if !strings.Contains(field, query) {
+			continue
+		}
+		results = append(results, msg)
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (s *MemoryStore) Recent(room string, n int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var msgs []Message
+	for _, msg := range s.messages[room] {
+		if !msg.Deleted {
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) <= n {
+		return msgs, nil
+	}
+	return append([]Message(nil), msgs[len(msgs)-n:]...), nil
+}
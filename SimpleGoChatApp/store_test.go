@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+func TestMemoryStoreAppendAndRecent(t *testing.T) {
+	store := NewMemoryStore()
+	if _, err := store.Append("#test", Message{SenderID: "alice", Content: "hi"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if _, err := store.Append("#test", Message{SenderID: "bob", Content: "hey"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	recent, err := store.Recent("#test", 10)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	if len(recent) != 2 {
+		t.Fatalf("len(recent) = %d, want 2", len(recent))
+	}
+	if recent[0].SenderID != "alice" || recent[1].SenderID != "bob" {
+		t.Errorf("Recent order = %+v, want alice then bob", recent)
+	}
+}
+
+func TestMemoryStoreSearchByContentAndUser(t *testing.T) {
+	store := NewMemoryStore()
+	store.Append("#test", Message{SenderID: "alice", Content: "hello world"})
+	store.Append("#test", Message{SenderID: "bob", Content: "goodbye"})
+
+	byContent, err := store.Search("#test", "hello", false, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(byContent) != 1 || byContent[0].SenderID != "alice" {
+		t.Errorf("Search by content = %+v, want one match from alice", byContent)
+	}
+
+	byUser, err := store.Search("#test", "bob", true, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(byUser) != 1 || byUser[0].Content != "goodbye" {
+		t.Errorf("Search by user = %+v, want one match from bob", byUser)
+	}
+}
+
+func TestMemoryStoreUpdateAndDelete(t *testing.T) {
+	store := NewMemoryStore()
+	id, err := store.Append("#test", Message{SenderID: "alice", Content: "helo"})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	if err := store.Update("#test", id, "hello"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	recent, err := store.Recent("#test", 10)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Content != "hello" {
+		t.Errorf("after Update, recent = %+v, want content %q", recent, "hello")
+	}
+
+	if err := store.Delete("#test", id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	recent, err = store.Recent("#test", 10)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	if len(recent) != 0 {
+		t.Errorf("after Delete, recent = %+v, want none", recent)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+const ansiReset = "\033[0m"
+
+// Theme controls the ANSI colors used when rendering a prompt or message
+// for a user who has picked it with /theme.
+type Theme struct {
+	Name        string
+	PromptColor string
+	UserColor   string
+}
+
+var themes = map[string]Theme{
+	"default":   {Name: "default"},
+	"dark":      {Name: "dark", PromptColor: "\033[36m", UserColor: "\033[37m"},
+	"solarized": {Name: "solarized", PromptColor: "\033[33m", UserColor: "\033[32m"},
+}
+
+// lookupTheme reports whether name is a known theme.
+func lookupTheme(name string) (Theme, bool) {
+	t, ok := themes[name]
+	return t, ok
+}
+
+func colorize(color, text string) string {
+	if color == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// GetPrompt renders the "> " prompt for a user with cfg's theme applied.
+func GetPrompt(cfg Config) string {
+	return colorize(themes[cfg.Theme].PromptColor, "> ")
+}
+
+// RenderMessage formats msg for display to a viewer with cfg, applying
+// their theme and timestamp preferences.
+func RenderMessage(msg Message, cfg Config) string {
+	sender := colorize(themes[cfg.Theme].UserColor, msg.SenderID)
+
+	switch cfg.Timestamp {
+	case "off":
+		return fmt.Sprintf("%s: %s", sender, msg.Content)
+	case "24h":
+		return fmt.Sprintf("[%s] %s: %s", msg.At.Format("15:04"), sender, msg.Content)
+	default: // "on"
+		return fmt.Sprintf("[%s] %s: %s", msg.Timestamp, sender, msg.Content)
+	}
+}